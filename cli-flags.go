@@ -0,0 +1,148 @@
+// cli-flags.go - 非交互式命令行参数模式，便于脚本化/CI 场景使用
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// cliFlags 保存命令行参数解析结果
+type cliFlags struct {
+	baseURL      string
+	authToken    string
+	model        string
+	haikuModel   string
+	sonnetModel  string
+	opusModel    string
+	skipValidate bool
+	configOnly   bool
+	yes          bool
+	print        bool
+	shell        string
+	system       bool
+
+	// set 记录是否有任何标志被显式传入，用于判断是否进入非交互模式
+	set bool
+}
+
+// parseCLIFlags 解析命令行参数
+func parseCLIFlags(args []string) (*cliFlags, error) {
+	fs := flag.NewFlagSet("claude-cli-setup", flag.ContinueOnError)
+
+	f := &cliFlags{}
+	fs.StringVar(&f.baseURL, "base-url", "", "API Base URL，例如 https://www.dmxapi.cn")
+	fs.StringVar(&f.authToken, "auth-token", "", "API 认证令牌")
+	fs.StringVar(&f.model, "model", "", "默认模型")
+	fs.StringVar(&f.haikuModel, "haiku-model", "", "Haiku 模型")
+	fs.StringVar(&f.sonnetModel, "sonnet-model", "", "Sonnet 模型")
+	fs.StringVar(&f.opusModel, "opus-model", "", "Opus 模型")
+	fs.BoolVar(&f.skipValidate, "skip-validate", false, "跳过 API 连接验证")
+	fs.BoolVar(&f.configOnly, "config-only", false, "仅写入配置，不做其他检查")
+	fs.BoolVar(&f.yes, "yes", false, "即使 API 连接验证失败也继续保存配置，不中断脚本")
+	fs.BoolVar(&f.print, "print", false, "将 export 语句打印到标准输出，而不是写入 rc 文件")
+	fs.StringVar(&f.shell, "shell", "", "强制指定持久化目标 (bash/zsh/fish/nu/powershell)，默认自动检测")
+	fs.BoolVar(&f.system, "system", false, "写入系统级配置文件而不是当前用户的配置文件")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	fs.Visit(func(*flag.Flag) { f.set = true })
+
+	return f, nil
+}
+
+// runNonInteractive 以非交互模式执行配置流程
+func runNonInteractive(f *cliFlags) int {
+	cfg := loadExistingConfig()
+
+	if f.baseURL != "" {
+		cfg.BaseURL = ensureScheme(f.baseURL)
+	}
+	if f.authToken != "" {
+		cfg.AuthToken = f.authToken
+	}
+	if f.model != "" {
+		cfg.Model = f.model
+	}
+	if f.haikuModel != "" {
+		cfg.HaikuModel = f.haikuModel
+	}
+	if f.sonnetModel != "" {
+		cfg.SonnetModel = f.sonnetModel
+	}
+	if f.opusModel != "" {
+		cfg.OpusModel = f.opusModel
+	}
+
+	if cfg.Model == "" {
+		cfg.Model = defaultModel
+	}
+	if cfg.HaikuModel == "" {
+		cfg.HaikuModel = defaultHaikuModel
+	}
+	if cfg.SonnetModel == "" {
+		cfg.SonnetModel = defaultSonnetModel
+	}
+	if cfg.OpusModel == "" {
+		cfg.OpusModel = defaultOpusModel
+	}
+
+	if cfg.BaseURL == "" || cfg.AuthToken == "" {
+		printError("非交互模式需要通过 --base-url 和 --auth-token 提供配置（或已存在于环境变量中）")
+		return 1
+	}
+
+	if err := validateURL(cfg.BaseURL); err != nil {
+		printError(err.Error())
+		return 1
+	}
+
+	if !f.skipValidate && !f.configOnly {
+		if err := validateAPIConnection(cfg.BaseURL, cfg.AuthToken); err != nil {
+			if !f.yes {
+				printError(fmt.Sprintf("API 连接验证失败: %v", err))
+				return 1
+			}
+			printWarning(fmt.Sprintf("API 连接验证失败，因 --yes 继续保存配置: %v", err))
+		} else {
+			printSuccess("API 连接验证成功!")
+		}
+	}
+
+	if f.print {
+		printExportLines(cfg)
+		return 0
+	}
+
+	if err := saveConfigWithOptions(cfg, f.shell, f.system); err != nil {
+		printError(fmt.Sprintf("保存配置失败: %v", err))
+		return 1
+	}
+
+	printSummary(cfg)
+	return 0
+}
+
+// printExportLines 将配置以 export 语句形式输出到标准输出
+func printExportLines(cfg Config) {
+	vars := []struct {
+		key   string
+		value string
+	}{
+		{envBaseURL, cfg.BaseURL},
+		{envAuthToken, cfg.AuthToken},
+		{envModel, cfg.Model},
+		{envHaikuModel, cfg.HaikuModel},
+		{envSonnetModel, cfg.SonnetModel},
+		{envOpusModel, cfg.OpusModel},
+	}
+
+	for _, v := range vars {
+		if v.value == "" {
+			continue
+		}
+		fmt.Fprintf(os.Stdout, "export %s='%s'\n", v.key, v.value)
+	}
+}
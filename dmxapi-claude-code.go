@@ -13,7 +13,6 @@ import (
 	"net/url"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"runtime"
 	"strings"
 	"syscall"
@@ -65,13 +64,15 @@ type Config struct {
 
 // ==================== 工具函数 ====================
 
-// printColor 打印带颜色的文本
+// printColor 打印带颜色的状态文本。
+// 统一写到 stderr：--print 等模式下 stdout 只能是可被
+// eval "$(...)" 消费的 export 语句，状态提示混进去会让它语法报错
 func printColor(color, text string) {
 	if runtime.GOOS == "windows" {
 		// Windows 下尝试启用 ANSI 颜色支持
-		fmt.Print(color + text + colorReset)
+		fmt.Fprint(os.Stderr, color+text+colorReset)
 	} else {
-		fmt.Print(color + text + colorReset)
+		fmt.Fprint(os.Stderr, color+text+colorReset)
 	}
 }
 
@@ -110,13 +111,15 @@ func readInput(prompt string) string {
 
 // readPassword 读取密码输入（隐藏字符）
 func readPassword(prompt string) string {
-	fmt.Print(prompt)
+	// 提示信息写到 stderr：export-token 等子命令的 stdout 会被命令替换捕获，
+	// 提示文字绝不能混入其中
+	fmt.Fprint(os.Stderr, prompt)
 
 	// 尝试从标准输入读取密码
 	fd := int(syscall.Stdin)
 	if term.IsTerminal(fd) {
 		password, err := term.ReadPassword(fd)
-		fmt.Println() // 换行
+		fmt.Fprintln(os.Stderr) // 换行
 		if err != nil {
 			return ""
 		}
@@ -198,101 +201,6 @@ func getEnvVar(key string) string {
 	return os.Getenv(key)
 }
 
-// setEnvVar 设置环境变量（跨平台）
-func setEnvVar(key, value string) error {
-	// 设置当前进程的环境变量
-	if err := os.Setenv(key, value); err != nil {
-		return err
-	}
-
-	// 持久化到系统
-	switch runtime.GOOS {
-	case "windows":
-		return setEnvVarWindows(key, value)
-	default:
-		return setEnvVarUnix(key, value)
-	}
-}
-
-// setEnvVarWindows 在 Windows 上设置用户环境变量
-func setEnvVarWindows(key, value string) error {
-	// 使用 PowerShell 执行，避免 cmd 的转义问题
-	psCmd := fmt.Sprintf(`[Environment]::SetEnvironmentVariable('%s', '%s', 'User')`,
-		strings.ReplaceAll(key, "'", "''"),
-		strings.ReplaceAll(value, "'", "''"))
-
-	// 优先使用 PowerShell
-	return runCommand("powershell", "-NoProfile", "-Command", psCmd)
-}
-
-// setEnvVarUnix 在 Unix 系统上设置环境变量
-func setEnvVarUnix(key, value string) error {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return err
-	}
-
-	// 构建 export 语句
-	exportLine := fmt.Sprintf("export %s='%s'\n", key, strings.ReplaceAll(value, "'", "'\\''"))
-
-	// 确定要写入的配置文件
-	var configFiles []string
-
-	switch runtime.GOOS {
-	case "darwin":
-		// macOS: 优先 zsh，兼容 bash
-		configFiles = []string{".zshrc", ".bash_profile"}
-	default:
-		// Linux: 优先 bashrc，兼容 profile
-		configFiles = []string{".bashrc", ".profile"}
-	}
-
-	// 写入配置文件
-	for _, configFile := range configFiles {
-		configPath := filepath.Join(homeDir, configFile)
-
-		// 检查文件是否存在
-		if _, err := os.Stat(configPath); os.IsNotExist(err) {
-			continue
-		}
-
-		// 读取现有内容
-		content, err := os.ReadFile(configPath)
-		if err != nil {
-			continue
-		}
-
-		// 检查是否已存在该环境变量的设置
-		marker := fmt.Sprintf("export %s=", key)
-		lines := strings.Split(string(content), "\n")
-		found := false
-		newLines := make([]string, 0, len(lines))
-
-		for _, line := range lines {
-			if strings.HasPrefix(strings.TrimSpace(line), marker) {
-				// 替换现有设置
-				newLines = append(newLines, strings.TrimSuffix(exportLine, "\n"))
-				found = true
-			} else {
-				newLines = append(newLines, line)
-			}
-		}
-
-		if !found {
-			// 添加到文件末尾
-			newLines = append(newLines, strings.TrimSuffix(exportLine, "\n"))
-		}
-
-		// 写回文件
-		newContent := strings.Join(newLines, "\n")
-		if err := os.WriteFile(configPath, []byte(newContent), 0644); err != nil {
-			return fmt.Errorf("写入 %s 失败: %v", configPath, err)
-		}
-	}
-
-	return nil
-}
-
 // runCommand 执行命令
 func runCommand(name string, args ...string) error {
 	cmd := exec.Command(name, args...)
@@ -587,10 +495,16 @@ func configureModels(cfg *Config) {
 
 // saveConfig 保存配置
 func saveConfig(cfg Config) error {
+	return saveConfigWithOptions(cfg, "", false)
+}
+
+// saveConfigWithOptions 保存配置，shellOverride 强制指定持久化目标
+// (bash/zsh/fish/nu/powershell)，systemWide 为 true 时写入系统级配置文件
+func saveConfigWithOptions(cfg Config, shellOverride string, systemWide bool) error {
 	fmt.Println()
 	printInfo("正在保存配置...")
 
-	// 保存所有环境变量
+	// 设置当前进程的环境变量
 	vars := map[string]string{
 		envBaseURL:     cfg.BaseURL,
 		envAuthToken:   cfg.AuthToken,
@@ -599,16 +513,59 @@ func saveConfig(cfg Config) error {
 		envSonnetModel: cfg.SonnetModel,
 		envOpusModel:   cfg.OpusModel,
 	}
-
 	for key, value := range vars {
 		if value == "" {
 			continue
 		}
-		if err := setEnvVar(key, value); err != nil {
+		if err := os.Setenv(key, value); err != nil {
 			return fmt.Errorf("设置 %s 失败: %v", key, err)
 		}
 	}
 
+	persister := detectPersister(shellOverride)
+
+	// Auth Token 默认单独加密存储，不落盘明文，rc 文件里只留一条
+	// "claude-cli-setup export-token" 的取值命令。但这条命令解密的是
+	// *当前用户* 家目录下的密钥文件；--system 写的是所有用户共享的
+	// 系统级配置，其他用户登录时执行同一条命令只会解密失败。
+	// systemWide 为 true 时索性跳过加密间接层，直接把明文写进系统配置——
+	// 这和该变量本身就是"系统范围共享"的语义一致。
+	//
+	// persister 不支持命令取值时 (目前只有 Windows 注册表：SetEnvironmentVariable
+	// 写入的是静态字符串，没有"启动时执行命令"的机制) 同样只能写明文，
+	// 这牺牲了"静态落盘不留明文"这一层保护——Token 本身仍然单独加密存一份
+	// (defaultSecretBackend 在 Windows 上优先用 DPAPI 密钥链)，但注册表里还会
+	// 有一份可被同机其他本地管理员读到的明文副本，需要明确告知用户。
+	assignments := map[string]string{}
+	commandAssignments := map[string]string{}
+	for key, value := range vars {
+		if value == "" {
+			continue
+		}
+		if key == envAuthToken {
+			if systemWide {
+				printWarning("--system 下 Auth Token 将以明文写入系统级配置，因为加密存储是按当前用户隔离的")
+				assignments[key] = value
+				continue
+			}
+			if err := persistAuthToken(value); err != nil {
+				return fmt.Errorf("加密保存 %s 失败: %v", key, err)
+			}
+			if persister.SupportsCommandSubstitution() {
+				commandAssignments[key] = exportTokenCommand
+			} else {
+				printWarning(fmt.Sprintf("%s 不支持取命令输出，Auth Token 将额外以明文写入该配置", persister.Name()))
+				assignments[key] = value
+			}
+			continue
+		}
+		assignments[key] = value
+	}
+
+	if err := persistEnv(persister, systemWide, assignments, commandAssignments); err != nil {
+		return fmt.Errorf("写入 %s 配置失败: %v", persister.Name(), err)
+	}
+
 	return nil
 }
 
@@ -648,6 +605,31 @@ func maskToken(token string) string {
 // ==================== 主程序 ====================
 
 func main() {
+	// export-token 子命令：解密并输出已保存的 Auth Token，供 rc 文件中的
+	// 命令替换语句调用
+	if len(os.Args) > 1 && os.Args[1] == "export-token" {
+		os.Exit(runExportTokenCommand())
+	}
+
+	// doctor 子命令：探测端点连通性和模型可用性
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		os.Exit(runDoctorCommand(os.Args[2:]))
+	}
+
+	// 档案管理子命令 (list/add/use/remove/show/current)
+	if code, ok := runProfileCommand(os.Args[1:]); ok {
+		os.Exit(code)
+	}
+
+	// 解析命令行参数，若传入了任意标志则进入非交互模式
+	flags, err := parseCLIFlags(os.Args[1:])
+	if err != nil {
+		os.Exit(1)
+	}
+	if flags.set {
+		os.Exit(runNonInteractive(flags))
+	}
+
 	// 显示欢迎信息
 	fmt.Println()
 	fmt.Println(strings.Repeat("=", 50))
@@ -0,0 +1,279 @@
+// doctor.go - `doctor` 子命令，对当前配置的端点和模型做结构化健康检查
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// doctorTimeout 是单项网络检查的超时时间
+const doctorTimeout = 10 * time.Second
+
+// doctorModelProbe 是单个模型的探测结果
+type doctorModelProbe struct {
+	EnvVar     string `json:"env_var"`
+	Model      string `json:"model"`
+	OK         bool   `json:"ok"`
+	StatusCode int    `json:"status_code,omitempty"`
+	LatencyMS  int64  `json:"latency_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// doctorReport 是一次完整体检的结果
+type doctorReport struct {
+	Host                string             `json:"host"`
+	DNSOK               bool               `json:"dns_ok"`
+	DNSError            string             `json:"dns_error,omitempty"`
+	TCPOK               bool               `json:"tcp_ok"`
+	TCPError            string             `json:"tcp_error,omitempty"`
+	TLSExpiry           string             `json:"tls_expiry,omitempty"`
+	TLSError            string             `json:"tls_error,omitempty"`
+	ModelsEndpointOK    bool               `json:"models_endpoint_ok"`
+	ModelsEndpointError string             `json:"models_endpoint_error,omitempty"`
+	AvailableModels     []string           `json:"available_models,omitempty"`
+	ModelProbes         []doctorModelProbe `json:"model_probes"`
+}
+
+// runDoctorCommand 处理 doctor 子命令
+func runDoctorCommand(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	jsonOut := fs.Bool("json", false, "以 JSON 格式输出结果")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	cfg := loadExistingConfig()
+	if cfg.BaseURL == "" || cfg.AuthToken == "" {
+		printError("未检测到 Base URL 或 Auth Token 配置，请先运行配置向导")
+		return 1
+	}
+
+	report := buildDoctorReport(cfg)
+
+	if *jsonOut {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			printError(err.Error())
+			return 1
+		}
+		fmt.Println(string(data))
+	} else {
+		printDoctorReport(report)
+	}
+
+	if !report.DNSOK || !report.TCPOK {
+		return 1
+	}
+	return 0
+}
+
+// buildDoctorReport 依次执行 DNS/TCP/TLS/模型探测并汇总结果
+func buildDoctorReport(cfg Config) doctorReport {
+	host := extractHost(cfg.BaseURL)
+	report := doctorReport{Host: host}
+
+	// host 可能带有显式端口 (例如 api.example.com:8443)，DNS 解析只认主机名
+	dnsHost := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		dnsHost = h
+	}
+
+	if _, err := net.LookupHost(dnsHost); err != nil {
+		report.DNSError = err.Error()
+	} else {
+		report.DNSOK = true
+	}
+
+	hostPort := host
+	if !strings.Contains(hostPort, ":") {
+		hostPort = hostPort + ":443"
+	}
+
+	conn, err := net.DialTimeout("tcp", hostPort, doctorTimeout)
+	if err != nil {
+		report.TCPError = err.Error()
+	} else {
+		report.TCPOK = true
+		conn.Close()
+	}
+
+	if report.TCPOK {
+		tlsConn, err := tls.DialWithDialer(&net.Dialer{Timeout: doctorTimeout}, "tcp", hostPort, &tls.Config{ServerName: strings.Split(host, ":")[0]})
+		if err != nil {
+			report.TLSError = err.Error()
+		} else {
+			certs := tlsConn.ConnectionState().PeerCertificates
+			if len(certs) > 0 {
+				report.TLSExpiry = certs[0].NotAfter.Format(time.RFC3339)
+			}
+			tlsConn.Close()
+		}
+	}
+
+	models, err := fetchAvailableModels(cfg.BaseURL, cfg.AuthToken)
+	if err != nil {
+		report.ModelsEndpointError = err.Error()
+	} else {
+		report.ModelsEndpointOK = true
+		report.AvailableModels = models
+	}
+
+	candidates := []struct {
+		envVar string
+		model  string
+	}{
+		{envModel, cfg.Model},
+		{envHaikuModel, cfg.HaikuModel},
+		{envSonnetModel, cfg.SonnetModel},
+		{envOpusModel, cfg.OpusModel},
+	}
+
+	for _, c := range candidates {
+		if c.model == "" {
+			continue
+		}
+		report.ModelProbes = append(report.ModelProbes, probeModel(cfg.BaseURL, cfg.AuthToken, c.envVar, c.model))
+	}
+
+	return report
+}
+
+// fetchAvailableModels 请求 /v1/models 枚举服务端实际支持的模型
+func fetchAvailableModels(baseURL, authToken string) ([]string, error) {
+	modelsURL := strings.TrimSuffix(baseURL, "/") + "/v1/models"
+
+	req, err := http.NewRequest("GET", modelsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-api-key", authToken)
+	req.Header.Set("anthropic-version", "2023-06-01")
+
+	client := &http.Client{Timeout: doctorTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(body.Data))
+	for _, m := range body.Data {
+		ids = append(ids, m.ID)
+	}
+	return ids, nil
+}
+
+// probeModel 对单个模型发起一次 1 token 的消息请求，记录延迟与状态码
+func probeModel(baseURL, authToken, envVar, model string) doctorModelProbe {
+	probe := doctorModelProbe{EnvVar: envVar, Model: model}
+
+	testURL := strings.TrimSuffix(baseURL, "/") + "/v1/messages"
+	requestBody := map[string]interface{}{
+		"model":      model,
+		"max_tokens": 1,
+		"messages": []map[string]string{
+			{"role": "user", "content": "Hi"},
+		},
+	}
+	bodyBytes, err := json.Marshal(requestBody)
+	if err != nil {
+		probe.Error = err.Error()
+		return probe
+	}
+
+	req, err := http.NewRequest("POST", testURL, bytes.NewReader(bodyBytes))
+	if err != nil {
+		probe.Error = err.Error()
+		return probe
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", authToken)
+	req.Header.Set("anthropic-version", "2023-06-01")
+
+	client := &http.Client{Timeout: doctorTimeout}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	probe.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		probe.Error = err.Error()
+		return probe
+	}
+	defer resp.Body.Close()
+
+	probe.StatusCode = resp.StatusCode
+	switch resp.StatusCode {
+	case 200, 429:
+		probe.OK = true
+	case 403:
+		probe.Error = "权限被拒绝"
+	case 404:
+		probe.Error = "模型不存在"
+	default:
+		probe.Error = fmt.Sprintf("HTTP %d", resp.StatusCode)
+	}
+
+	return probe
+}
+
+// printDoctorReport 以彩色表格形式打印体检结果
+func printDoctorReport(report doctorReport) {
+	fmt.Println()
+	printInfo(fmt.Sprintf("正在体检: %s", report.Host))
+	fmt.Println()
+
+	if report.DNSOK {
+		printSuccess("DNS 解析正常")
+	} else {
+		printError(fmt.Sprintf("DNS 解析失败: %s", report.DNSError))
+	}
+
+	if report.TCPOK {
+		printSuccess("TCP 连接正常")
+	} else {
+		printError(fmt.Sprintf("TCP 连接失败: %s", report.TCPError))
+	}
+
+	if report.TLSError != "" {
+		printWarning(fmt.Sprintf("TLS 证书检查失败: %s", report.TLSError))
+	} else if report.TLSExpiry != "" {
+		printSuccess(fmt.Sprintf("TLS 证书有效期至: %s", report.TLSExpiry))
+	}
+
+	if report.ModelsEndpointOK {
+		printSuccess(fmt.Sprintf("模型列表接口正常 (%d 个模型)", len(report.AvailableModels)))
+	} else {
+		printWarning(fmt.Sprintf("模型列表接口不可用: %s", report.ModelsEndpointError))
+	}
+
+	fmt.Println()
+	fmt.Printf("  %-35s %-10s %-10s %s\n", "模型", "状态", "延迟(ms)", "说明")
+	for _, p := range report.ModelProbes {
+		status := "OK"
+		if !p.OK {
+			status = "FAIL"
+		}
+		fmt.Printf("  %-35s %-10s %-10d %s\n", p.EnvVar+"="+p.Model, status, p.LatencyMS, p.Error)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,389 @@
+// env-persist.go - 可插拔的环境变量持久化后端，覆盖 bash/zsh/fish/nushell/PowerShell
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// 管理区块的哨兵标记。`#` 在 bash/zsh/fish/nushell/PowerShell 中都是注释符，
+// 重新运行时整体替换该区块，而不是逐行扫描匹配。
+const (
+	managedBlockStart = "# >>> claude-cli-setup >>>"
+	managedBlockEnd   = "# <<< claude-cli-setup <<<"
+)
+
+// EnvPersister 定义了把环境变量写入某种 shell/系统配置文件的方式
+type EnvPersister interface {
+	// Name 是该持久化方式的标识，用于 --shell 覆盖和提示信息
+	Name() string
+	// ConfigPath 返回要写入的配置文件路径；systemWide 为 true 时返回系统级路径
+	ConfigPath(systemWide bool) (string, error)
+	// RenderAssignment 渲染一行字面值赋值语句
+	RenderAssignment(key, value string) string
+	// RenderCommandAssignment 渲染一行“取命令输出”的赋值语句，用于加密 Token 取回
+	RenderCommandAssignment(key, command string) string
+	// SupportsCommandSubstitution 表示该后端能否在加载配置时执行命令取值
+	// (rc 文件的 "$(...)" / "(...)"）。不支持的后端 (如 Windows 注册表)
+	// 只能收到字面值，调用方需要改为直接写入明文
+	SupportsCommandSubstitution() bool
+}
+
+// directPersister 由不依赖配置文件、直接调用系统 API 写入环境变量的后端实现
+// (目前只有 Windows 注册表)；persistEnv 检测到它时跳过文件读写逻辑
+type directPersister interface {
+	ApplyDirect(systemWide bool, assignments map[string]string) error
+}
+
+// detectPersister 根据 override（--shell 的值）或当前环境自动选择持久化方式
+func detectPersister(override string) EnvPersister {
+	if override != "" {
+		if p := persisterByName(override); p != nil {
+			return p
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		// 默认写用户级注册表：cmd.exe、GUI 程序、计划任务等非 PowerShell
+		// 场景都读取这里，而不是只有加载了 profile 的 PowerShell 会话能看到。
+		// 需要 profile 方式时显式传 --shell=powershell。
+		return windowsRegistryPersister{}
+	}
+
+	shell := filepath.Base(os.Getenv("SHELL"))
+	switch {
+	case strings.Contains(shell, "fish"):
+		return fishPersister{}
+	case strings.Contains(shell, "zsh"):
+		return zshPersister{}
+	case strings.Contains(shell, "nu"):
+		return nushellPersister{}
+	default:
+		return bashPersister{}
+	}
+}
+
+// persisterByName 按名称查找持久化实现，找不到时返回 nil
+func persisterByName(name string) EnvPersister {
+	switch strings.ToLower(name) {
+	case "bash":
+		return bashPersister{}
+	case "zsh":
+		return zshPersister{}
+	case "fish":
+		return fishPersister{}
+	case "nu", "nushell":
+		return nushellPersister{}
+	case "powershell", "pwsh":
+		return powershellPersister{}
+	case "registry", "windows":
+		return windowsRegistryPersister{}
+	}
+	return nil
+}
+
+// persistEnv 把字面值变量和命令取值变量写入 persister 对应的配置文件，
+// 或者（对于 directPersister）直接调用系统 API 设置
+func persistEnv(p EnvPersister, systemWide bool, assignments, commandAssignments map[string]string) error {
+	if len(assignments) == 0 && len(commandAssignments) == 0 {
+		return nil
+	}
+
+	if err := requireElevationForSystemWide(systemWide); err != nil {
+		return err
+	}
+
+	if dp, ok := p.(directPersister); ok {
+		return dp.ApplyDirect(systemWide, assignments)
+	}
+
+	path, err := p.ConfigPath(systemWide)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(assignments)+len(commandAssignments))
+	for key := range assignments {
+		keys = append(keys, key)
+	}
+	for key := range commandAssignments {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if value, ok := assignments[key]; ok {
+			lines = append(lines, p.RenderAssignment(key, value))
+			continue
+		}
+		lines = append(lines, p.RenderCommandAssignment(key, commandAssignments[key]))
+	}
+
+	return writeManagedBlock(path, lines)
+}
+
+// writeManagedBlock 用哨兵注释包裹管理区块，整体替换已有区块或追加到文件末尾
+func writeManagedBlock(path string, lines []string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	var existing string
+	if data, err := os.ReadFile(path); err == nil {
+		existing = string(data)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	block := managedBlockStart + "\n" + strings.Join(lines, "\n") + "\n" + managedBlockEnd
+
+	newContent, replaced := replaceManagedBlock(existing, block)
+	if !replaced {
+		if newContent != "" && !strings.HasSuffix(newContent, "\n") {
+			newContent += "\n"
+		}
+		newContent += block + "\n"
+	}
+
+	return os.WriteFile(path, []byte(newContent), 0644)
+}
+
+// replaceManagedBlock 替换 content 中已有的管理区块，返回是否找到并替换
+func replaceManagedBlock(content, block string) (string, bool) {
+	startIdx := strings.Index(content, managedBlockStart)
+	if startIdx == -1 {
+		return content, false
+	}
+	rest := content[startIdx:]
+	endIdx := strings.Index(rest, managedBlockEnd)
+	if endIdx == -1 {
+		return content, false
+	}
+	endIdx = startIdx + endIdx + len(managedBlockEnd)
+	return content[:startIdx] + block + content[endIdx:], true
+}
+
+// requireElevationForSystemWide 在写入系统级配置前做一次权限探测
+func requireElevationForSystemWide(systemWide bool) error {
+	if !systemWide {
+		return nil
+	}
+
+	if runtime.GOOS == "windows" {
+		if !isElevatedWindows() {
+			return fmt.Errorf("写入系统级配置需要以管理员身份运行")
+		}
+		return nil
+	}
+
+	if os.Geteuid() != 0 {
+		return fmt.Errorf("写入系统级配置需要 root 权限，请使用 sudo 重新运行")
+	}
+	return nil
+}
+
+// isElevatedWindows 通过 `net session` 是否成功来判断当前进程是否具有管理员权限
+func isElevatedWindows() bool {
+	return runCommand("net", "session") == nil
+}
+
+// ==================== bash ====================
+
+type bashPersister struct{}
+
+func (bashPersister) Name() string { return "bash" }
+
+func (bashPersister) ConfigPath(systemWide bool) (string, error) {
+	if systemWide {
+		return "/etc/profile.d/claude-cli-setup.sh", nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".bashrc"), nil
+}
+
+func (bashPersister) RenderAssignment(key, value string) string {
+	return fmt.Sprintf("export %s='%s'", key, strings.ReplaceAll(value, "'", `'\''`))
+}
+
+func (bashPersister) RenderCommandAssignment(key, command string) string {
+	return fmt.Sprintf(`export %s="$(%s)"`, key, command)
+}
+
+func (bashPersister) SupportsCommandSubstitution() bool { return true }
+
+// ==================== zsh ====================
+
+type zshPersister struct{}
+
+func (zshPersister) Name() string { return "zsh" }
+
+func (zshPersister) ConfigPath(systemWide bool) (string, error) {
+	if systemWide {
+		return "/etc/zshenv", nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".zshrc"), nil
+}
+
+func (zshPersister) RenderAssignment(key, value string) string {
+	return bashPersister{}.RenderAssignment(key, value)
+}
+
+func (zshPersister) RenderCommandAssignment(key, command string) string {
+	return bashPersister{}.RenderCommandAssignment(key, command)
+}
+
+func (zshPersister) SupportsCommandSubstitution() bool { return true }
+
+// ==================== fish ====================
+
+type fishPersister struct{}
+
+func (fishPersister) Name() string { return "fish" }
+
+func (fishPersister) ConfigPath(systemWide bool) (string, error) {
+	if systemWide {
+		return "/etc/fish/conf.d/claude-cli-setup.fish", nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".config", "fish", "conf.d", "claude.fish"), nil
+}
+
+func (fishPersister) RenderAssignment(key, value string) string {
+	return fmt.Sprintf("set -gx %s '%s'", key, strings.ReplaceAll(value, "'", `\'`))
+}
+
+func (fishPersister) RenderCommandAssignment(key, command string) string {
+	return fmt.Sprintf("set -gx %s (%s)", key, command)
+}
+
+func (fishPersister) SupportsCommandSubstitution() bool { return true }
+
+// ==================== nushell ====================
+
+type nushellPersister struct{}
+
+func (nushellPersister) Name() string { return "nu" }
+
+func (nushellPersister) ConfigPath(systemWide bool) (string, error) {
+	if systemWide {
+		// nushell 没有类似 /etc/profile.d 的机器级配置目录约定，
+		// 之前这里直接忽略 systemWide 写用户配置，会让 requireElevationForSystemWide
+		// 的 root 检查形同虚设 (以为在配系统级，实际改的是调用者自己的文件)。
+		// 没有真正的系统级落点时明确报错，比悄悄写错地方更安全。
+		return "", fmt.Errorf("nushell 不支持 --system：没有可用的系统级配置文件位置，请改用 --shell=bash/zsh/fish 或手动配置")
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".config", "nushell", "env.nu"), nil
+}
+
+func (nushellPersister) RenderAssignment(key, value string) string {
+	return fmt.Sprintf("$env.%s = %q", key, value)
+}
+
+func (nushellPersister) RenderCommandAssignment(key, command string) string {
+	return fmt.Sprintf("$env.%s = (%s)", key, command)
+}
+
+func (nushellPersister) SupportsCommandSubstitution() bool { return true }
+
+// ==================== PowerShell ====================
+
+type powershellPersister struct{}
+
+func (powershellPersister) Name() string { return "powershell" }
+
+func (powershellPersister) ConfigPath(systemWide bool) (string, error) {
+	if systemWide {
+		// 机器级 PowerShell 配置文件，写入需要管理员权限
+		return `C:\Windows\System32\WindowsPowerShell\v1.0\profile.ps1`, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, "Documents", "WindowsPowerShell", "Microsoft.PowerShell_profile.ps1"), nil
+}
+
+func (powershellPersister) RenderAssignment(key, value string) string {
+	return fmt.Sprintf("$env:%s = '%s'", key, strings.ReplaceAll(value, "'", "''"))
+}
+
+func (powershellPersister) RenderCommandAssignment(key, command string) string {
+	return fmt.Sprintf("$env:%s = (%s)", key, command)
+}
+
+func (powershellPersister) SupportsCommandSubstitution() bool { return true }
+
+// ==================== Windows 注册表 ====================
+//
+// 默认的 Windows 持久化方式：直接调用 [Environment]::SetEnvironmentVariable
+// 写入用户（或机器）级注册表项，这样 cmd.exe、GUI 程序、计划任务等不会
+// 加载 PowerShell profile 的进程也能读到配置好的变量，和旧版行为一致。
+// 注册表没有命令取值机制，所以它不支持命令替换（见 SupportsCommandSubstitution），
+// 调用方会改为把 Auth Token 以明文形式直接写入。
+
+type windowsRegistryPersister struct{}
+
+func (windowsRegistryPersister) Name() string { return "registry" }
+
+func (windowsRegistryPersister) ConfigPath(systemWide bool) (string, error) {
+	if systemWide {
+		return "HKLM\\System\\CurrentControlSet\\Control\\Session Manager\\Environment", nil
+	}
+	return "HKCU\\Environment", nil
+}
+
+func (windowsRegistryPersister) RenderAssignment(key, value string) string {
+	return fmt.Sprintf("%s=%s", key, value)
+}
+
+func (windowsRegistryPersister) RenderCommandAssignment(key, command string) string {
+	return fmt.Sprintf("%s=(%s)", key, command)
+}
+
+func (windowsRegistryPersister) SupportsCommandSubstitution() bool { return false }
+
+// ApplyDirect 对每个变量调用一次 SetEnvironmentVariable，写入注册表
+func (p windowsRegistryPersister) ApplyDirect(systemWide bool, assignments map[string]string) error {
+	scope := "User"
+	if systemWide {
+		scope = "Machine"
+	}
+
+	keys := make([]string, 0, len(assignments))
+	for key := range assignments {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := assignments[key]
+		psCmd := fmt.Sprintf(`[Environment]::SetEnvironmentVariable('%s', '%s', '%s')`,
+			strings.ReplaceAll(key, "'", "''"),
+			strings.ReplaceAll(value, "'", "''"),
+			scope)
+		if err := runCommand("powershell", "-NoProfile", "-Command", psCmd); err != nil {
+			return fmt.Errorf("写入注册表变量 %s 失败: %v", key, err)
+		}
+	}
+
+	return nil
+}
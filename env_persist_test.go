@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestReplaceManagedBlockAppendsWhenMissing(t *testing.T) {
+	content := "export PATH=/usr/bin\n"
+	block := managedBlockStart + "\nexport FOO=bar\n" + managedBlockEnd
+
+	got, replaced := replaceManagedBlock(content, block)
+	if replaced {
+		t.Fatalf("expected replaced=false when no existing block, got true")
+	}
+	if got != content {
+		t.Fatalf("expected content unchanged when no existing block, got %q", got)
+	}
+}
+
+func TestReplaceManagedBlockReplacesExisting(t *testing.T) {
+	content := "export PATH=/usr/bin\n" +
+		managedBlockStart + "\nexport FOO=old\n" + managedBlockEnd +
+		"\nexport TAIL=1\n"
+	block := managedBlockStart + "\nexport FOO=new\n" + managedBlockEnd
+
+	got, replaced := replaceManagedBlock(content, block)
+	if !replaced {
+		t.Fatalf("expected replaced=true when existing block present")
+	}
+
+	want := "export PATH=/usr/bin\n" + block + "\nexport TAIL=1\n"
+	if got != want {
+		t.Fatalf("unexpected result:\nwant %q\ngot  %q", want, got)
+	}
+}
+
+func TestReplaceManagedBlockMissingEnd(t *testing.T) {
+	content := "export PATH=/usr/bin\n" + managedBlockStart + "\nexport FOO=old\n"
+	block := managedBlockStart + "\nexport FOO=new\n" + managedBlockEnd
+
+	got, replaced := replaceManagedBlock(content, block)
+	if replaced {
+		t.Fatalf("expected replaced=false when end sentinel missing, got true")
+	}
+	if got != content {
+		t.Fatalf("expected content unchanged when end sentinel missing, got %q", got)
+	}
+}
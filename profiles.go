@@ -0,0 +1,421 @@
+// profiles.go - 多配置档案管理，将多组 BaseURL/Token/模型配置保存到本地配置文件
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Profile 是一组完整的配置档案。
+// AuthToken 不落在这里——它通过 profileSecretBackend 单独加密存储，
+// 避免 config.toml 里出现明文凭据。
+type Profile struct {
+	BaseURL     string
+	Model       string
+	HaikuModel  string
+	SonnetModel string
+	OpusModel   string
+}
+
+// ProfileStore 是持久化到磁盘的全部配置档案
+type ProfileStore struct {
+	Current  string
+	Profiles map[string]Profile
+}
+
+// profileConfigDir 返回配置目录，Windows 下使用 %APPDATA%
+func profileConfigDir() (string, error) {
+	if runtime.GOOS == "windows" {
+		appData := os.Getenv("APPDATA")
+		if appData == "" {
+			return "", fmt.Errorf("未找到 %%APPDATA%% 环境变量")
+		}
+		return filepath.Join(appData, "claude-cli-setup"), nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".config", "claude-cli-setup"), nil
+}
+
+// profileConfigPath 返回 config.toml 的完整路径
+func profileConfigPath() (string, error) {
+	dir, err := profileConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.toml"), nil
+}
+
+// loadProfileStore 从磁盘加载配置档案，文件不存在时返回空 store
+func loadProfileStore() (*ProfileStore, error) {
+	path, err := profileConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	store := &ProfileStore{Profiles: make(map[string]Profile)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, err
+	}
+
+	if err := decodeProfileTOML(data, store); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %v", err)
+	}
+
+	return store, nil
+}
+
+// saveProfileStore 将配置档案写回磁盘
+func saveProfileStore(store *ProfileStore) error {
+	dir, err := profileConfigDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	path, err := profileConfigPath()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, encodeProfileTOML(store), 0600)
+}
+
+// ==================== 极简 TOML 编解码 ====================
+//
+// 配置文件结构固定且扁平（顶层 current 字段 + [profiles.<name>] 表），
+// 没有必要引入第三方 TOML 依赖，这里手写一个够用的编解码器。
+
+func encodeProfileTOML(store *ProfileStore) []byte {
+	var b strings.Builder
+
+	if store.Current != "" {
+		fmt.Fprintf(&b, "current = %q\n\n", store.Current)
+	}
+
+	names := make([]string, 0, len(store.Profiles))
+	for name := range store.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := store.Profiles[name]
+		fmt.Fprintf(&b, "[profiles.%s]\n", name)
+		fmt.Fprintf(&b, "base_url = %q\n", p.BaseURL)
+		fmt.Fprintf(&b, "model = %q\n", p.Model)
+		fmt.Fprintf(&b, "haiku_model = %q\n", p.HaikuModel)
+		fmt.Fprintf(&b, "sonnet_model = %q\n", p.SonnetModel)
+		fmt.Fprintf(&b, "opus_model = %q\n", p.OpusModel)
+		b.WriteString("\n")
+	}
+
+	return []byte(b.String())
+}
+
+func decodeProfileTOML(data []byte, store *ProfileStore) error {
+	var section string
+
+	for _, rawLine := range strings.Split(string(data), "\n") {
+		line := strings.TrimSpace(rawLine)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		value, err := strconv.Unquote(strings.TrimSpace(parts[1]))
+		if err != nil {
+			value = strings.Trim(strings.TrimSpace(parts[1]), `"`)
+		}
+
+		if section == "" {
+			if key == "current" {
+				store.Current = value
+			}
+			continue
+		}
+
+		const prefix = "profiles."
+		if !strings.HasPrefix(section, prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(section, prefix)
+		p := store.Profiles[name]
+
+		switch key {
+		case "base_url":
+			p.BaseURL = value
+		case "model":
+			p.Model = value
+		case "haiku_model":
+			p.HaikuModel = value
+		case "sonnet_model":
+			p.SonnetModel = value
+		case "opus_model":
+			p.OpusModel = value
+		}
+		store.Profiles[name] = p
+	}
+
+	return nil
+}
+
+// ==================== 子命令 ====================
+
+// runProfileCommand 处理 list/add/use/remove/show/current 子命令
+// 返回值表示进程退出码；ok 为 false 时表示该参数不是档案子命令
+func runProfileCommand(args []string) (code int, ok bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+
+	switch args[0] {
+	case "list":
+		return profileList(), true
+	case "add":
+		return profileAdd(args[1:]), true
+	case "use":
+		return profileUse(args[1:]), true
+	case "remove":
+		return profileRemove(args[1:]), true
+	case "show":
+		return profileShow(args[1:]), true
+	case "current":
+		return profileCurrent(), true
+	default:
+		return 0, false
+	}
+}
+
+func profileList() int {
+	store, err := loadProfileStore()
+	if err != nil {
+		printError(err.Error())
+		return 1
+	}
+
+	if len(store.Profiles) == 0 {
+		printInfo("尚未配置任何档案，使用 'add <name>' 创建一个")
+		return 0
+	}
+
+	names := make([]string, 0, len(store.Profiles))
+	for name := range store.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		marker := "  "
+		if name == store.Current {
+			marker = "* "
+		}
+		fmt.Printf("%s%s\n", marker, name)
+	}
+	return 0
+}
+
+func profileAdd(args []string) int {
+	if len(args) != 1 {
+		printError("用法: claude-cli-setup add <name>")
+		return 1
+	}
+	name := args[0]
+
+	store, err := loadProfileStore()
+	if err != nil {
+		printError(err.Error())
+		return 1
+	}
+
+	cfg := Config{}
+	cfg.BaseURL = getNewBaseURL(cfg.BaseURL)
+	hostname := extractHost(cfg.BaseURL)
+	cfg.AuthToken = getNewAuthToken(cfg.AuthToken, hostname)
+
+	fmt.Println()
+	if err := validateAPIConnection(cfg.BaseURL, cfg.AuthToken); err != nil {
+		printWarning(fmt.Sprintf("API 连接验证失败: %v", err))
+	} else {
+		printSuccess("API 连接验证成功!")
+	}
+
+	configureModels(&cfg)
+
+	if err := profileSecretBackend(name).Store(cfg.AuthToken); err != nil {
+		printError(fmt.Sprintf("加密保存档案 Token 失败: %v", err))
+		return 1
+	}
+
+	store.Profiles[name] = Profile{
+		BaseURL:     cfg.BaseURL,
+		Model:       cfg.Model,
+		HaikuModel:  cfg.HaikuModel,
+		SonnetModel: cfg.SonnetModel,
+		OpusModel:   cfg.OpusModel,
+	}
+	if store.Current == "" {
+		store.Current = name
+	}
+
+	if err := saveProfileStore(store); err != nil {
+		printError(fmt.Sprintf("保存档案失败: %v", err))
+		return 1
+	}
+
+	printSuccess(fmt.Sprintf("档案 %s 已保存", name))
+	return 0
+}
+
+func profileUse(args []string) int {
+	if len(args) != 1 {
+		printError("用法: claude-cli-setup use <name>")
+		return 1
+	}
+	name := args[0]
+
+	store, err := loadProfileStore()
+	if err != nil {
+		printError(err.Error())
+		return 1
+	}
+
+	p, exists := store.Profiles[name]
+	if !exists {
+		printError(fmt.Sprintf("档案 %s 不存在", name))
+		return 1
+	}
+
+	authToken, err := profileSecretBackend(name).Retrieve()
+	if err != nil {
+		printError(fmt.Sprintf("解密档案 Token 失败: %v", err))
+		return 1
+	}
+
+	cfg := Config{
+		BaseURL:     p.BaseURL,
+		AuthToken:   authToken,
+		Model:       p.Model,
+		HaikuModel:  p.HaikuModel,
+		SonnetModel: p.SonnetModel,
+		OpusModel:   p.OpusModel,
+	}
+
+	if err := saveConfig(cfg); err != nil {
+		printError(fmt.Sprintf("切换档案失败: %v", err))
+		return 1
+	}
+
+	store.Current = name
+	if err := saveProfileStore(store); err != nil {
+		printError(fmt.Sprintf("保存档案失败: %v", err))
+		return 1
+	}
+
+	printSuccess(fmt.Sprintf("已切换到档案 %s", name))
+	return 0
+}
+
+func profileRemove(args []string) int {
+	if len(args) != 1 {
+		printError("用法: claude-cli-setup remove <name>")
+		return 1
+	}
+	name := args[0]
+
+	store, err := loadProfileStore()
+	if err != nil {
+		printError(err.Error())
+		return 1
+	}
+
+	if _, exists := store.Profiles[name]; !exists {
+		printError(fmt.Sprintf("档案 %s 不存在", name))
+		return 1
+	}
+
+	delete(store.Profiles, name)
+	if store.Current == name {
+		store.Current = ""
+	}
+
+	if err := saveProfileStore(store); err != nil {
+		printError(fmt.Sprintf("保存档案失败: %v", err))
+		return 1
+	}
+
+	if err := profileSecretBackend(name).Delete(); err != nil {
+		printWarning(fmt.Sprintf("清除档案 %s 的加密 Token 失败: %v", name, err))
+	}
+
+	printSuccess(fmt.Sprintf("档案 %s 已删除", name))
+	return 0
+}
+
+func profileShow(args []string) int {
+	if len(args) != 1 {
+		printError("用法: claude-cli-setup show <name>")
+		return 1
+	}
+	name := args[0]
+
+	store, err := loadProfileStore()
+	if err != nil {
+		printError(err.Error())
+		return 1
+	}
+
+	p, exists := store.Profiles[name]
+	if !exists {
+		printError(fmt.Sprintf("档案 %s 不存在", name))
+		return 1
+	}
+
+	fmt.Printf("  %-15s = %s\n", "base_url", p.BaseURL)
+	fmt.Printf("  %-15s = %s\n", "auth_token", "(已加密存储，使用 'use "+name+"' 切换以解密)")
+	fmt.Printf("  %-15s = %s\n", "model", p.Model)
+	fmt.Printf("  %-15s = %s\n", "haiku_model", p.HaikuModel)
+	fmt.Printf("  %-15s = %s\n", "sonnet_model", p.SonnetModel)
+	fmt.Printf("  %-15s = %s\n", "opus_model", p.OpusModel)
+	return 0
+}
+
+func profileCurrent() int {
+	store, err := loadProfileStore()
+	if err != nil {
+		printError(err.Error())
+		return 1
+	}
+
+	if store.Current == "" {
+		printInfo("当前未选择任何档案")
+		return 0
+	}
+
+	fmt.Println(store.Current)
+	return 0
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEncodeDecodeProfileTOMLRoundTrip(t *testing.T) {
+	store := &ProfileStore{
+		Current: "dmxapi",
+		Profiles: map[string]Profile{
+			"dmxapi": {
+				BaseURL:     "https://www.dmxapi.cn",
+				Model:       "claude-sonnet-4",
+				HaikuModel:  "claude-haiku-4",
+				SonnetModel: "claude-sonnet-4",
+				OpusModel:   "claude-opus-4",
+			},
+			"official": {
+				BaseURL:     "https://api.anthropic.com",
+				Model:       "claude-sonnet-4",
+				HaikuModel:  "claude-haiku-4",
+				SonnetModel: "claude-sonnet-4",
+				OpusModel:   "claude-opus-4",
+			},
+		},
+	}
+
+	data := encodeProfileTOML(store)
+
+	got := &ProfileStore{Profiles: make(map[string]Profile)}
+	if err := decodeProfileTOML(data, got); err != nil {
+		t.Fatalf("decodeProfileTOML failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(store, got) {
+		t.Fatalf("round trip mismatch:\nwant %#v\ngot  %#v", store, got)
+	}
+}
+
+func TestDecodeProfileTOMLEmpty(t *testing.T) {
+	store := &ProfileStore{Profiles: make(map[string]Profile)}
+	if err := decodeProfileTOML([]byte(""), store); err != nil {
+		t.Fatalf("decodeProfileTOML on empty input failed: %v", err)
+	}
+	if store.Current != "" || len(store.Profiles) != 0 {
+		t.Fatalf("expected empty store, got %#v", store)
+	}
+}
@@ -0,0 +1,229 @@
+// secrets.go - 加密存储 Auth Token，避免其以明文形式写入 rc 文件/注册表
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// scrypt 派生参数
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltLen      = 16
+)
+
+// errNoSecretStored 表示尚未保存过任何加密 Token
+var errNoSecretStored = errors.New("尚未保存加密 Token")
+
+// SecretBackend 是 Token 加密存储的后端接口。
+// 默认优先使用系统密钥链 (keyringBackend，见 secrets_keyring.go)，
+// 密钥链不可用时退回基于口令派生密钥的加密文件 (encryptedFileBackend)。
+type SecretBackend interface {
+	Store(token string) error
+	Retrieve() (string, error)
+	// Delete 清除已保存的 Token；本来就没有保存过时视为成功
+	Delete() error
+}
+
+// defaultSecretBackend 返回当前生效配置 (环境变量) 使用的后端实现
+func defaultSecretBackend() SecretBackend {
+	path, err := secretsFilePath()
+	if err != nil {
+		path = "secrets.enc"
+	}
+	return newKeyringBackend("claude-cli-setup", &encryptedFileBackend{path: path})
+}
+
+// profileSecretBackend 返回指定档案专用的加密存储后端，
+// 使每个档案的 Token 各自存储，互不影响
+func profileSecretBackend(profileName string) SecretBackend {
+	path, err := profileSecretsFilePath(profileName)
+	if err != nil {
+		path = "secrets-" + profileName + ".enc"
+	}
+	return newKeyringBackend("claude-cli-setup:profile:"+profileName, &encryptedFileBackend{path: path})
+}
+
+// secretsFilePath 返回当前生效配置加密 Token 文件的路径
+func secretsFilePath() (string, error) {
+	dir, err := profileConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "secrets.enc"), nil
+}
+
+// profileSecretsFilePath 返回某个档案加密 Token 文件的路径
+func profileSecretsFilePath(profileName string) (string, error) {
+	dir, err := profileConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "profiles", profileName+".secrets.enc"), nil
+}
+
+// secretEnvelope 是加密文件的磁盘格式
+type secretEnvelope struct {
+	Salt  string `json:"salt"`
+	Nonce string `json:"nonce"`
+	Data  string `json:"data"`
+}
+
+// envPassphrase 是非交互场景下（CI/脚本）用于跳过密码提示的环境变量
+const envPassphrase = "CLAUDE_CLI_SETUP_PASSPHRASE"
+
+// readPassphrase 优先使用 CLAUDE_CLI_SETUP_PASSPHRASE，否则回退到交互式提示，
+// 使得 --base-url/--auth-token 等非交互参数模式不会被密码提示卡住
+func readPassphrase(prompt string) string {
+	if p := os.Getenv(envPassphrase); p != "" {
+		return p
+	}
+	return readPassword(prompt)
+}
+
+// encryptedFileBackend 使用 scrypt 派生密钥 + AES-GCM 加密存储 Token
+type encryptedFileBackend struct {
+	path string
+}
+
+func (b *encryptedFileBackend) Store(token string) error {
+	if err := os.MkdirAll(filepath.Dir(b.path), 0700); err != nil {
+		return err
+	}
+
+	passphrase := readPassphrase("请输入密码以加密保存 Auth Token: ")
+	if passphrase == "" {
+		return fmt.Errorf("密码不能为空（非交互模式下请设置 %s 环境变量）", envPassphrase)
+	}
+
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	ciphertext := gcm.Seal(nil, nonce, []byte(token), nil)
+
+	envelope := secretEnvelope{
+		Salt:  base64.StdEncoding.EncodeToString(salt),
+		Nonce: base64.StdEncoding.EncodeToString(nonce),
+		Data:  base64.StdEncoding.EncodeToString(ciphertext),
+	}
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(b.path, data, 0600)
+}
+
+func (b *encryptedFileBackend) Retrieve() (string, error) {
+	raw, err := os.ReadFile(b.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", errNoSecretStored
+		}
+		return "", err
+	}
+
+	var envelope secretEnvelope
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return "", fmt.Errorf("加密文件损坏: %v", err)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(envelope.Salt)
+	if err != nil {
+		return "", fmt.Errorf("加密文件损坏: %v", err)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(envelope.Nonce)
+	if err != nil {
+		return "", fmt.Errorf("加密文件损坏: %v", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(envelope.Data)
+	if err != nil {
+		return "", fmt.Errorf("加密文件损坏: %v", err)
+	}
+
+	passphrase := readPassphrase("请输入密码以解密 Auth Token: ")
+
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("解密失败: 密码错误或文件损坏")
+	}
+
+	return string(plaintext), nil
+}
+
+func (b *encryptedFileBackend) Delete() error {
+	if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// newGCM 使用 scrypt 从密码派生出 AES-GCM 所需的密钥
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// ==================== 持久化接入 ====================
+
+// exportTokenCommand 是写入 shell 配置中用于取回明文 Token 的命令调用，
+// 实际的 shell 行渲染由 EnvPersister 负责 (见 env-persist.go)
+const exportTokenCommand = "claude-cli-setup export-token"
+
+// persistAuthToken 将 Auth Token 加密保存到本地密钥库
+func persistAuthToken(token string) error {
+	if err := defaultSecretBackend().Store(token); err != nil {
+		return fmt.Errorf("加密保存 Token 失败: %v", err)
+	}
+	return nil
+}
+
+// runExportTokenCommand 实现 export-token 子命令：解密并把明文 Token 打印到标准输出
+func runExportTokenCommand() int {
+	token, err := defaultSecretBackend().Retrieve()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	fmt.Println(token)
+	return 0
+}
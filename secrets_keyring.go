@@ -0,0 +1,226 @@
+// secrets_keyring.go - 系统密钥链后端，避免每次新开终端都要求输入密码
+//
+// encryptedFileBackend 的口令在每次 Retrieve 时都要交互式输入，这对"新开一个
+// 终端就要设置好 ANTHROPIC_AUTH_TOKEN"这个场景并不合适：非 tty 场景下会静默
+// 用空密码解密失败，tty 场景下则会在每个新终端卡住等待输入。
+// keyringBackend 优先把 Token 交给操作系统自己的密钥链管理
+// (macOS Keychain / Linux Secret Service / Windows DPAPI)，
+// 这些机制都绑定在当前登录用户的会话上，不需要再额外输入密码。
+// 密钥链不可用时 (无相应工具、无 GUI 会话等)，透明回退到 fallback 后端。
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// keyringBackend 按当前操作系统选择密钥链实现，不可用时退回 fallback
+type keyringBackend struct {
+	service  string
+	fallback SecretBackend
+}
+
+// newKeyringBackend 返回优先使用系统密钥链、必要时退回 fallback 的后端
+func newKeyringBackend(service string, fallback SecretBackend) SecretBackend {
+	return &keyringBackend{service: service, fallback: fallback}
+}
+
+func (b *keyringBackend) Store(token string) error {
+	if !keyringAvailable() {
+		return b.fallback.Store(token)
+	}
+	if err := keyringStore(b.service, token); err != nil {
+		printWarning(fmt.Sprintf("系统密钥链存储失败，改用加密文件: %v", err))
+		return b.fallback.Store(token)
+	}
+	return nil
+}
+
+func (b *keyringBackend) Retrieve() (string, error) {
+	if !keyringAvailable() {
+		return b.fallback.Retrieve()
+	}
+	token, err := keyringRetrieve(b.service)
+	if err != nil {
+		return b.fallback.Retrieve()
+	}
+	return token, nil
+}
+
+// Delete 清除密钥链条目和 fallback 文件两处——Store 当时具体写到了
+// 哪一个并不一定能确定（密钥链运行期间可能从可用变为不可用），
+// 两处都清理一遍更可靠，其中任意一处本来就不存在都不算错误
+func (b *keyringBackend) Delete() error {
+	var keyringErr error
+	if keyringAvailable() {
+		keyringErr = keyringDelete(b.service)
+	}
+	if err := b.fallback.Delete(); err != nil {
+		return err
+	}
+	return keyringErr
+}
+
+// keyringAvailable 检查当前系统上密钥链后端所需的工具是否存在
+func keyringAvailable() bool {
+	switch runtime.GOOS {
+	case "darwin":
+		_, err := exec.LookPath("security")
+		return err == nil
+	case "windows":
+		_, err := exec.LookPath("powershell")
+		return err == nil
+	default:
+		_, err := exec.LookPath("secret-tool")
+		return err == nil
+	}
+}
+
+// keyringAccount 返回用于区分密钥链条目的账户名，通常是当前系统用户名
+func keyringAccount() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+func keyringStore(service, token string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return runCommand("security", "add-generic-password",
+			"-a", keyringAccount(), "-s", service, "-w", token, "-U")
+	case "windows":
+		return windowsDPAPIStore(service, token)
+	default:
+		cmd := exec.Command("secret-tool", "store",
+			"--label=claude-cli-setup", "service", service, "account", keyringAccount())
+		cmd.Stdin = strings.NewReader(token)
+		return cmd.Run()
+	}
+}
+
+func keyringRetrieve(service string) (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := exec.Command("security", "find-generic-password",
+			"-a", keyringAccount(), "-s", service, "-w").Output()
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(out)), nil
+	case "windows":
+		return windowsDPAPIRetrieve(service)
+	default:
+		out, err := exec.Command("secret-tool", "lookup",
+			"service", service, "account", keyringAccount()).Output()
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+}
+
+// keyringDelete 清除密钥链里的条目；条目本来就不存在时视为成功
+func keyringDelete(service string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		err := exec.Command("security", "delete-generic-password",
+			"-a", keyringAccount(), "-s", service).Run()
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 44 {
+			// security 在找不到条目时返回 44
+			return nil
+		}
+		return err
+	case "windows":
+		path, err := windowsDPAPIPath(service)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	default:
+		err := exec.Command("secret-tool", "clear",
+			"service", service, "account", keyringAccount()).Run()
+		if _, ok := err.(*exec.ExitError); ok {
+			// secret-tool 找不到条目时也会以非零状态退出，对 Delete 而言这不算失败
+			return nil
+		}
+		return err
+	}
+}
+
+// ==================== Windows DPAPI ====================
+//
+// Windows 没有现成的命令行密钥链工具，这里借助 PowerShell 调用 DPAPI
+// (ConvertTo/From-SecureString 默认按当前用户+本机密钥加解密)，
+// 把密文存成普通文件——文件本身可以随意读取，但只有加密它的那个
+// Windows 用户账户能在同一台机器上解密出明文，效果等价于密钥链。
+
+func windowsDPAPIPath(service string) (string, error) {
+	dir, err := profileConfigDir()
+	if err != nil {
+		return "", err
+	}
+	safeName := strings.NewReplacer(":", "_", "/", "_", "\\", "_").Replace(service)
+	return filepath.Join(dir, "keyring-"+safeName+".dat"), nil
+}
+
+func windowsDPAPIStore(service, token string) error {
+	path, err := windowsDPAPIPath(service)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	psCmd := fmt.Sprintf(
+		`ConvertTo-SecureString -String '%s' -AsPlainText -Force | ConvertFrom-SecureString`,
+		strings.ReplaceAll(token, "'", "''"))
+	cmd := exec.Command("powershell", "-NoProfile", "-Command", psCmd)
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, bytes.TrimSpace(out.Bytes()), 0600)
+}
+
+func windowsDPAPIRetrieve(service string) (string, error) {
+	path, err := windowsDPAPIPath(service)
+	if err != nil {
+		return "", err
+	}
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", errNoSecretStored
+		}
+		return "", err
+	}
+
+	var out bytes.Buffer
+	psCmd := fmt.Sprintf(
+		`$s = ConvertTo-SecureString -String '%s'; `+
+			`[Runtime.InteropServices.Marshal]::PtrToStringAuto([Runtime.InteropServices.Marshal]::SecureStringToGlobalAllocUnicode($s))`,
+		strings.ReplaceAll(string(blob), "'", "''"))
+	cmd := exec.Command("powershell", "-NoProfile", "-Command", psCmd)
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("DPAPI 解密失败: %v", err)
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}